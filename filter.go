@@ -0,0 +1,138 @@
+// +build !js
+
+package goa
+
+import (
+	"strings"
+	"sync"
+
+	iradix "github.com/hashicorp/go-immutable-radix"
+)
+
+// filter holds the package-level allow/block configuration for metric key
+// prefixes and labels, mirroring the filtering concept from go-metrics. An
+// immutable radix tree gives O(k) longest-prefix matching against the
+// dot-joined key path without locking on the read path beyond an RWMutex
+// guarding the current tree pointers.
+var filter = &metricFilter{defaultAllow: true}
+
+type metricFilter struct {
+	mu sync.RWMutex
+
+	allowedPrefixes *iradix.Tree
+	blockedPrefixes *iradix.Tree
+	defaultAllow    bool
+
+	allowedLabels map[string]bool
+	blockedLabels map[string]bool
+}
+
+// SetFilter configures which normalized, dot-joined metric key paths are
+// allowed through to the underlying Metrics implementation. blockedPrefixes
+// are checked first, then allowedPrefixes; defaultAllow decides the outcome
+// when neither matches. Matching is longest-prefix, so "consul.http" blocks
+// "consul.http.get" but not "consul.raft".
+func SetFilter(allowedPrefixes, blockedPrefixes []string, defaultAllow bool) {
+	allowed := iradix.New()
+	for _, prefix := range allowedPrefixes {
+		allowed, _, _ = allowed.Insert([]byte(prefix), nil)
+	}
+
+	blocked := iradix.New()
+	for _, prefix := range blockedPrefixes {
+		blocked, _, _ = blocked.Insert([]byte(prefix), nil)
+	}
+
+	filter.mu.Lock()
+	defer filter.mu.Unlock()
+	filter.allowedPrefixes = allowed
+	filter.blockedPrefixes = blocked
+	filter.defaultAllow = defaultAllow
+}
+
+// SetLabelFilter configures which label names are allowed through to the
+// underlying Metrics implementation. blockedLabels are stripped
+// unconditionally; when allowedLabels is non-empty, any label not in it is
+// stripped as well.
+func SetLabelFilter(allowedLabels, blockedLabels []string) {
+	allowed := make(map[string]bool, len(allowedLabels))
+	for _, name := range allowedLabels {
+		allowed[name] = true
+	}
+
+	blocked := make(map[string]bool, len(blockedLabels))
+	for _, name := range blockedLabels {
+		blocked[name] = true
+	}
+
+	filter.mu.Lock()
+	defer filter.mu.Unlock()
+	filter.allowedLabels = allowed
+	filter.blockedLabels = blocked
+}
+
+// allowMetric reports whether the normalized key passes the configured
+// prefix filter. It must be called after normalizeKeys.
+//
+// Like go-metrics, a block match doesn't automatically win: when both an
+// allowed and a blocked prefix match, the longer (more specific) one wins,
+// so e.g. blocking "consul.http" while allowing "consul.http.healthcheck"
+// re-allows the latter. Equally-specific matches are resolved in favor of
+// blocking.
+func allowMetric(key []string) bool {
+	joined := []byte(strings.Join(key, "."))
+
+	filter.mu.RLock()
+	defer filter.mu.RUnlock()
+
+	blockedLen, blocked := longestMatchLen(filter.blockedPrefixes, joined)
+	allowedLen, allowed := longestMatchLen(filter.allowedPrefixes, joined)
+
+	switch {
+	case blocked && allowed:
+		return allowedLen > blockedLen
+	case blocked:
+		return false
+	case allowed:
+		return true
+	default:
+		return filter.defaultAllow
+	}
+}
+
+// longestMatchLen returns the length of the longest prefix of key found in
+// tree, and whether any prefix matched at all.
+func longestMatchLen(tree *iradix.Tree, key []byte) (int, bool) {
+	if tree == nil || tree.Len() == 0 {
+		return 0, false
+	}
+	prefix, _, ok := tree.Root().LongestPrefix(key)
+	return len(prefix), ok
+}
+
+// filterLabels strips labels blocked or not allowed by the configured label
+// filter. It must be called after normalizeLabels.
+func filterLabels(labels []Label) []Label {
+	if len(labels) == 0 {
+		return labels
+	}
+
+	filter.mu.RLock()
+	defer filter.mu.RUnlock()
+
+	if len(filter.allowedLabels) == 0 && len(filter.blockedLabels) == 0 {
+		return labels
+	}
+
+	kept := labels[:0:0]
+	for _, l := range labels {
+		if filter.blockedLabels[l.Name] {
+			continue
+		}
+		if len(filter.allowedLabels) > 0 && !filter.allowedLabels[l.Name] {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept
+}
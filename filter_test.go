@@ -0,0 +1,37 @@
+// +build !js
+
+package goa
+
+import "testing"
+
+func resetFilter() {
+	SetFilter(nil, nil, true)
+	SetLabelFilter(nil, nil)
+}
+
+func TestAllowMetricMoreSpecificAllowReAllowsBlockedPrefix(t *testing.T) {
+	defer resetFilter()
+
+	SetFilter([]string{"consul.http.healthcheck"}, []string{"consul.http"}, true)
+
+	if allowMetric([]string{"consul", "http", "get"}) {
+		t.Fatalf("expected consul.http.get to be blocked by consul.http")
+	}
+	if !allowMetric([]string{"consul", "http", "healthcheck"}) {
+		t.Fatalf("expected consul.http.healthcheck to be re-allowed by the more specific allow prefix")
+	}
+}
+
+func TestAddSampleBlockedPrefixNeverReachesMetrics(t *testing.T) {
+	defer resetFilter()
+	defer SetMetrics(&metricDiscarder{})
+
+	f := &fakeMetrics{}
+	SetMetrics(f)
+	SetFilter(nil, []string{"noisy"}, true)
+
+	AddSample([]string{"noisy", "key"}, 1.0)
+	if f.samples != 0 {
+		t.Fatalf("blocked metric should not have reached the underlying Metrics implementation")
+	}
+}
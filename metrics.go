@@ -5,6 +5,7 @@ package goa
 import (
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -17,8 +18,16 @@ const (
 )
 
 var (
-	// interface for metrics.Metrics
-	metriks Metrics = &metricDiscarder{}
+	// global holds the current Metrics implementation. It is swapped via
+	// SetMetrics/NewMetrics and read on every emit call, so an atomic.Value
+	// is used instead of a plain variable to avoid a data race between the
+	// goroutine reconfiguring metrics and goroutines emitting them.
+	//
+	// atomic.Value requires every Store to use the same concrete type, but
+	// SetMetrics/NewMetrics/UseReporter/EnableSignalDump each store a
+	// different Metrics implementation, so the value actually stored is
+	// always a metricsHolder wrapping the interface.
+	global atomic.Value
 
 	// used for normalizing names by matching '*' and '/' so they can be replaced.
 	invalidCharactersRE = regexp.MustCompile(`[\*/]`)
@@ -27,35 +36,81 @@ var (
 	metricsNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_:]*$`)
 )
 
+// metricsHolder is the fixed concrete type stored in global, so that
+// swapping in different Metrics implementations never changes the dynamic
+// type atomic.Value sees.
+type metricsHolder struct {
+	m Metrics
+}
+
+func init() {
+	global.Store(metricsHolder{&metricDiscarder{}})
+}
+
+// current returns the currently configured Metrics implementation.
+func current() Metrics {
+	return global.Load().(metricsHolder).m
+}
+
+// Label is a name/value pair attached to a metric, e.g. a Prometheus label
+// or a DogStatsD tag.
+type Label = metrics.Label
+
 // Metrics generic interface for decoupling Goa from go-metrics.
 type Metrics interface {
 	SetGauge(key []string, val float32)
+	SetGaugeWithLabels(key []string, val float32, labels []Label)
 	EmitKey(key []string, val float32)
 	IncrCounter(key []string, val float32)
+	IncrCounterWithLabels(key []string, val float32, labels []Label)
 	AddSample(key []string, val float32)
+	AddSampleWithLabels(key []string, val float32, labels []Label)
 	MeasureSince(key []string, start time.Time)
+	MeasureSinceWithLabels(key []string, start time.Time, labels []Label)
+	Shutdown()
 }
 
 // used for dealing with race conditions.
 type metricDiscarder struct{}
 func (md *metricDiscarder) SetGauge(key []string, val float32) {}
+func (md *metricDiscarder) SetGaugeWithLabels(key []string, val float32, labels []Label) {}
 func (md *metricDiscarder) EmitKey(key []string, val float32) {}
 func (md *metricDiscarder) IncrCounter(key []string, val float32) {}
+func (md *metricDiscarder) IncrCounterWithLabels(key []string, val float32, labels []Label) {}
 func (md *metricDiscarder) AddSample(key []string, val float32) {}
+func (md *metricDiscarder) AddSampleWithLabels(key []string, val float32, labels []Label) {}
 func (md *metricDiscarder) MeasureSince(key []string, start time.Time) {}
+func (md *metricDiscarder) MeasureSinceWithLabels(key []string, start time.Time, labels []Label) {}
+func (md *metricDiscarder) Shutdown() {}
 
 
 // NewMetrics initializes goa's metrics instance with the supplied
 // configuration and metrics sink
 // This method is deprecated and SetMetrics should be used instead.
-func NewMetrics(conf *metrics.Config, sink metrics.MetricSink) (err error) {
-	metriks, err = metrics.NewGlobal(conf, sink)
-	return
+func NewMetrics(conf *metrics.Config, sink metrics.MetricSink) error {
+	m, err := metrics.NewGlobal(conf, sink)
+	if err != nil {
+		return err
+	}
+	global.Store(metricsHolder{m})
+	return nil
 }
 
 // SetMetrics initializes goa's metrics instance with the supplied metrics adapter interface.
 func SetMetrics(m Metrics) {
-	metriks = m
+	global.Store(metricsHolder{m})
+}
+
+// Shutdown flushes any buffered metrics held by the underlying sink (e.g.
+// statsd, Dogstatsd) and stops background collection. Short-lived services
+// should call this before exiting, typically via:
+//     defer goa.Shutdown()
+// in main, so that metrics emitted right before exit aren't lost. Shutdown
+// itself just delegates to the current Metrics implementation on every
+// call, so whether a repeat call is actually safe depends on that
+// implementation's own Shutdown being idempotent.
+func Shutdown() {
+	current().Shutdown()
 }
 
 // AddSample adds a sample to an aggregated metric
@@ -63,30 +118,71 @@ func SetMetrics(m Metrics) {
 // Usage:
 //     AddSample([]string{"my","namespace","key"}, 15.0)
 func AddSample(key []string, val float32) {
-	if metriks != nil {
-		normalizeKeys(key)
-		metriks.AddSample(key, val)
+	normalizeKeys(key)
+	if !allowMetric(key) {
+		return
 	}
+	current().AddSample(key, val)
+}
+
+// AddSampleWithLabels adds a sample to an aggregated metric, along with
+// labels, reporting count, min, max, mean, and std deviation
+// Usage:
+//     AddSampleWithLabels([]string{"my","namespace","key"}, 15.0, []Label{{"region", "west"}})
+func AddSampleWithLabels(key []string, val float32, labels []Label) {
+	normalizeKeys(key)
+	normalizeLabels(labels)
+	if !allowMetric(key) {
+		return
+	}
+	current().AddSampleWithLabels(key, val, filterLabels(labels))
 }
 
 // EmitKey emits a key/value pair
 // Usage:
 //     EmitKey([]string{"my","namespace","key"}, 15.0)
 func EmitKey(key []string, val float32) {
-	if metriks != nil {
-		normalizeKeys(key)
-		metriks.EmitKey(key, val)
+	normalizeKeys(key)
+	if !allowMetric(key) {
+		return
+	}
+	current().EmitKey(key, val)
+}
+
+// IncrCounterWithLabels increments the counter named by `key`, along with labels
+// Usage:
+//     IncrCounterWithLabels([]string{"my","namespace","counter"}, 1.0, []Label{{"region", "west"}})
+func IncrCounterWithLabels(key []string, val float32, labels []Label) {
+	normalizeKeys(key)
+	normalizeLabels(labels)
+	if !allowMetric(key) {
+		return
 	}
+	current().IncrCounterWithLabels(key, val, filterLabels(labels))
 }
 
 // IncrCounter increments the counter named by `key`
 // Usage:
 //     IncrCounter([]key{"my","namespace","counter"}, 1.0)
 func IncrCounter(key []string, val float32) {
-	if metriks != nil {
-		normalizeKeys(key)
-		metriks.IncrCounter(key, val)
+	normalizeKeys(key)
+	if !allowMetric(key) {
+		return
+	}
+	current().IncrCounter(key, val)
+}
+
+// MeasureSinceWithLabels creates a timing metric, along with labels, that
+// records the duration of elapsed time since `start`
+// Usage:
+//     MeasureSinceWithLabels([]string{"my","namespace","action}, time.Now(), []Label{{"region", "west"}})
+func MeasureSinceWithLabels(key []string, start time.Time, labels []Label) {
+	normalizeKeys(key)
+	normalizeLabels(labels)
+	if !allowMetric(key) {
+		return
 	}
+	current().MeasureSinceWithLabels(key, start, filterLabels(labels))
 }
 
 // MeasureSince creates a timing metric that records
@@ -96,20 +192,35 @@ func IncrCounter(key []string, val float32) {
 // Frequently used in a defer:
 //    defer MeasureSince([]string{"my","namespace","action}, time.Now())
 func MeasureSince(key []string, start time.Time) {
-	if metriks != nil {
-		normalizeKeys(key)
-		metriks.MeasureSince(key, start)
+	normalizeKeys(key)
+	if !allowMetric(key) {
+		return
 	}
+	current().MeasureSince(key, start)
+}
+
+// SetGaugeWithLabels sets the named gauge, along with labels, to the
+// specified value
+// Usage:
+//     SetGaugeWithLabels([]string{"my","namespace"}, 2.0, []Label{{"region", "west"}})
+func SetGaugeWithLabels(key []string, val float32, labels []Label) {
+	normalizeKeys(key)
+	normalizeLabels(labels)
+	if !allowMetric(key) {
+		return
+	}
+	current().SetGaugeWithLabels(key, val, filterLabels(labels))
 }
 
 // SetGauge sets the named gauge to the specified value
 // Usage:
 //     SetGauge([]string{"my","namespace"}, 2.0)
 func SetGauge(key []string, val float32) {
-	if metriks != nil {
-		normalizeKeys(key)
-		metriks.SetGauge(key, val)
+	normalizeKeys(key)
+	if !allowMetric(key) {
+		return
 	}
+	current().SetGauge(key, val)
 }
 
 // This function is used to make metric names safe for all metric services. Specifically, prometheus does
@@ -125,3 +236,15 @@ func normalizeKeys(key []string) {
 		}
 	}
 }
+
+// normalizeLabels applies the same name sanitization as normalizeKeys to the
+// Name of each label, since label names are subject to the same constraints
+// (e.g. Prometheus label names) as metric names.
+func normalizeLabels(labels []Label) {
+	for i, l := range labels {
+		if !metricsNameRE.MatchString(l.Name) {
+			name := strings.Replace(l.Name, allMatcher, allReplacement, -1)
+			labels[i].Name = invalidCharactersRE.ReplaceAllString(name, normalizedToken)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// +build !js
+
+package metrics
+
+import (
+	"net/http"
+	"time"
+)
+
+// Fanout is a Reporter that dispatches every call to a fixed set of
+// underlying Reporters, so a service can push to e.g. Prometheus and a
+// go-metrics-backed sink at the same time.
+type Fanout []Reporter
+
+// NewFanout returns a Reporter that fans out to reporters, in order.
+func NewFanout(reporters ...Reporter) Fanout {
+	return Fanout(reporters)
+}
+
+func (f Fanout) Init(opts Options) error {
+	for _, r := range f {
+		if err := r.Init(opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f Fanout) SetGauge(key []string, val float32, labels []Label) {
+	for _, r := range f {
+		r.SetGauge(key, val, labels)
+	}
+}
+
+func (f Fanout) IncrCounter(key []string, val float32, labels []Label) {
+	for _, r := range f {
+		r.IncrCounter(key, val, labels)
+	}
+}
+
+func (f Fanout) AddSample(key []string, val float32, labels []Label) {
+	for _, r := range f {
+		r.AddSample(key, val, labels)
+	}
+}
+
+func (f Fanout) MeasureSince(key []string, start time.Time, labels []Label) {
+	for _, r := range f {
+		r.MeasureSince(key, start, labels)
+	}
+}
+
+// Handler returns the first non-nil Handler among the fanned-out
+// Reporters, since only one scrape endpoint can reasonably be mounted.
+func (f Fanout) Handler() http.Handler {
+	for _, r := range f {
+		if h := r.Handler(); h != nil {
+			return h
+		}
+	}
+	return nil
+}
+
+func (f Fanout) Shutdown() {
+	for _, r := range f {
+		r.Shutdown()
+	}
+}
@@ -0,0 +1,62 @@
+// +build !js
+
+package metrics
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// recordingReporter is a Reporter that just counts calls, used to verify
+// Fanout dispatches to every underlying Reporter.
+type recordingReporter struct {
+	inits    int
+	gauges   int
+	counters int
+	samples  int
+	timings  int
+	shutdown int
+	handler  http.Handler
+}
+
+func (r *recordingReporter) Init(opts Options) error                          { r.inits++; return nil }
+func (r *recordingReporter) SetGauge(key []string, val float32, l []Label)    { r.gauges++ }
+func (r *recordingReporter) IncrCounter(key []string, val float32, l []Label) { r.counters++ }
+func (r *recordingReporter) AddSample(key []string, val float32, l []Label)   { r.samples++ }
+func (r *recordingReporter) MeasureSince(key []string, start time.Time, l []Label) {
+	r.timings++
+}
+func (r *recordingReporter) Handler() http.Handler { return r.handler }
+func (r *recordingReporter) Shutdown()             { r.shutdown++ }
+
+func TestFanoutDispatchesToEveryReporter(t *testing.T) {
+	a, b := &recordingReporter{}, &recordingReporter{}
+	f := NewFanout(a, b)
+
+	if err := f.Init(Options{ServiceName: "svc"}); err != nil {
+		t.Fatal(err)
+	}
+	f.SetGauge([]string{"g"}, 1, nil)
+	f.IncrCounter([]string{"c"}, 1, nil)
+	f.AddSample([]string{"s"}, 1, nil)
+	f.MeasureSince([]string{"t"}, time.Now(), nil)
+	f.Shutdown()
+
+	for name, r := range map[string]*recordingReporter{"a": a, "b": b} {
+		if r.inits != 1 || r.gauges != 1 || r.counters != 1 || r.samples != 1 || r.timings != 1 || r.shutdown != 1 {
+			t.Fatalf("reporter %s did not receive every dispatched call: %+v", name, r)
+		}
+	}
+}
+
+func TestFanoutHandlerReturnsFirstNonNil(t *testing.T) {
+	h := http.NotFoundHandler()
+	a := &recordingReporter{}
+	b := &recordingReporter{handler: h}
+	f := NewFanout(a, b)
+
+	if got := f.Handler(); got == nil {
+		t.Fatal("expected Handler to return b's non-nil handler")
+	}
+}
@@ -0,0 +1,69 @@
+// +build !js
+
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	gometrics "github.com/armon/go-metrics"
+)
+
+// GoMetrics is a Reporter backed by github.com/armon/go-metrics, preserving
+// the sinks (statsd, Dogstatsd, in-mem, ...) goa supported before the
+// Reporter abstraction existed.
+type GoMetrics struct {
+	m *gometrics.Metrics
+}
+
+// NewGoMetrics builds a GoMetrics Reporter from a go-metrics config and
+// sink. conf may be nil, in which case gometrics.DefaultConfig is used.
+func NewGoMetrics(conf *gometrics.Config, sink gometrics.MetricSink) (*GoMetrics, error) {
+	if conf == nil {
+		conf = gometrics.DefaultConfig("")
+	}
+	m, err := gometrics.New(conf, sink)
+	if err != nil {
+		return nil, err
+	}
+	return &GoMetrics{m: m}, nil
+}
+
+// Init applies opts.ServiceName as the go-metrics service name; the rest of
+// Options has no go-metrics equivalent and is ignored.
+func (g *GoMetrics) Init(opts Options) error {
+	g.m.Config.ServiceName = opts.ServiceName
+	return nil
+}
+
+func (g *GoMetrics) SetGauge(key []string, val float32, labels []Label) {
+	g.m.SetGaugeWithLabels(key, val, toGoMetricsLabels(labels))
+}
+
+func (g *GoMetrics) IncrCounter(key []string, val float32, labels []Label) {
+	g.m.IncrCounterWithLabels(key, val, toGoMetricsLabels(labels))
+}
+
+func (g *GoMetrics) AddSample(key []string, val float32, labels []Label) {
+	g.m.AddSampleWithLabels(key, val, toGoMetricsLabels(labels))
+}
+
+func (g *GoMetrics) MeasureSince(key []string, start time.Time, labels []Label) {
+	g.m.MeasureSinceWithLabels(key, start, toGoMetricsLabels(labels))
+}
+
+// Handler returns nil: go-metrics sinks are push-based and don't expose a
+// scrape endpoint.
+func (g *GoMetrics) Handler() http.Handler { return nil }
+
+func (g *GoMetrics) Shutdown() {
+	g.m.Shutdown()
+}
+
+func toGoMetricsLabels(labels []Label) []gometrics.Label {
+	out := make([]gometrics.Label, len(labels))
+	for i, l := range labels {
+		out[i] = gometrics.Label{Name: l.Name, Value: l.Value}
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+// +build !js
+
+package metrics
+
+import (
+	"net/http"
+	"time"
+)
+
+// Noop is a Reporter that discards every metric. It's the zero-value-safe
+// default for code that wants the Reporter interface without requiring a
+// real backend, e.g. in tests.
+type Noop struct{}
+
+// NewNoop returns a Reporter that discards all metrics.
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+func (n *Noop) Init(opts Options) error { return nil }
+
+func (n *Noop) SetGauge(key []string, val float32, labels []Label)         {}
+func (n *Noop) IncrCounter(key []string, val float32, labels []Label)      {}
+func (n *Noop) AddSample(key []string, val float32, labels []Label)       {}
+func (n *Noop) MeasureSince(key []string, start time.Time, labels []Label) {}
+
+func (n *Noop) Handler() http.Handler { return nil }
+
+func (n *Noop) Shutdown() {}
@@ -0,0 +1,238 @@
+// +build !js
+
+package metrics
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus is a Reporter that registers Counter/Gauge/Histogram/Summary
+// families on demand and exposes them for scraping via Handler.
+type Prometheus struct {
+	opts     Options
+	registry *prometheus.Registry
+
+	mu         sync.Mutex
+	gauges     map[string]*prometheus.GaugeVec
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+	summaries  map[string]*prometheus.SummaryVec
+}
+
+// NewPrometheus returns an un-initialized Prometheus Reporter; call Init
+// before use.
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		gauges:     make(map[string]*prometheus.GaugeVec),
+		counters:   make(map[string]*prometheus.CounterVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+		summaries:  make(map[string]*prometheus.SummaryVec),
+	}
+}
+
+// Init registers a fresh Registry and remembers opts for key prefixing and
+// default tags/percentiles.
+func (p *Prometheus) Init(opts Options) error {
+	p.opts = opts
+	p.registry = prometheus.NewRegistry()
+	return nil
+}
+
+// Handler returns the http.Handler that serves p's registry for scraping.
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// Shutdown is a no-op: Prometheus is pull-based, so there is nothing to
+// flush or tear down.
+func (p *Prometheus) Shutdown() {}
+
+func (p *Prometheus) SetGauge(key []string, val float32, labels []Label) {
+	p.gaugeVec(key, labels).With(p.labelMap(labels)).Set(float64(val))
+}
+
+func (p *Prometheus) IncrCounter(key []string, val float32, labels []Label) {
+	p.counterVec(key, labels).With(p.labelMap(labels)).Add(float64(val))
+}
+
+func (p *Prometheus) AddSample(key []string, val float32, labels []Label) {
+	p.summaryVec(key, labels).With(p.labelMap(labels)).Observe(float64(val))
+}
+
+func (p *Prometheus) MeasureSince(key []string, start time.Time, labels []Label) {
+	// Observe in seconds, Prometheus's base unit for durations: the
+	// DefBuckets used by histogramVec are scaled for seconds, so
+	// observing milliseconds here would push every typical request
+	// timing into the +Inf bucket.
+	elapsed := time.Since(start).Seconds()
+	p.histogramVec(key, labels).With(p.labelMap(labels)).Observe(elapsed)
+}
+
+// name joins key into a Prometheus metric name, prepending Prefix only when
+// one was configured so an empty Prefix doesn't leave a leading "_".
+func (p *Prometheus) name(key []string) string {
+	parts := make([]string, 0, len(key)+1)
+	if p.opts.Prefix != "" {
+		parts = append(parts, p.opts.Prefix)
+	}
+	parts = append(parts, key...)
+	return strings.Join(parts, "_")
+}
+
+func (p *Prometheus) labelNames(labels []Label) []string {
+	names := make([]string, 0, len(labels)+len(p.opts.DefaultTags))
+	for _, l := range p.opts.DefaultTags {
+		names = append(names, l.Name)
+	}
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+func (p *Prometheus) labelMap(labels []Label) prometheus.Labels {
+	m := make(prometheus.Labels, len(labels)+len(p.opts.DefaultTags))
+	for _, l := range p.opts.DefaultTags {
+		m[l.Name] = l.Value
+	}
+	for _, l := range labels {
+		m[l.Name] = l.Value
+	}
+	return m
+}
+
+// vecCacheKey identifies a metric family by name *and* its set of label
+// names. A Prometheus Vec fixes its label dimensionality at creation, so a
+// call site that varies the labels it passes for the same key (including a
+// labeled call followed by an unlabeled one) must get a distinct Vec rather
+// than reuse one registered for a different label set.
+func vecCacheKey(name string, labelNames []string) string {
+	sorted := append([]string(nil), labelNames...)
+	sort.Strings(sorted)
+	return name + "\x00" + strings.Join(sorted, ",")
+}
+
+// logVecTypeCollision warns that name was already registered under a
+// different collector type (e.g. the same key used as both a gauge and a
+// counter). The caller still gets back a usable, freshly-built vec so the
+// observation itself succeeds, but that vec was never registered with the
+// registry, so it will never show up in a scrape.
+func logVecTypeCollision(name string) {
+	log.Printf("goa/metrics: %q is already registered under a different metric type; this observation will not be scraped", name)
+}
+
+func (p *Prometheus) gaugeVec(key []string, labels []Label) *prometheus.GaugeVec {
+	name := p.name(key)
+	labelNames := p.labelNames(labels)
+	cacheKey := vecCacheKey(name, labelNames)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if vec, ok := p.gauges[cacheKey]; ok {
+		return vec
+	}
+
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name}, labelNames)
+	if err := p.registry.Register(vec); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if existing, ok := are.ExistingCollector.(*prometheus.GaugeVec); ok {
+				vec = existing
+			} else {
+				logVecTypeCollision(name)
+			}
+		}
+	}
+	p.gauges[cacheKey] = vec
+	return vec
+}
+
+func (p *Prometheus) counterVec(key []string, labels []Label) *prometheus.CounterVec {
+	name := p.name(key)
+	labelNames := p.labelNames(labels)
+	cacheKey := vecCacheKey(name, labelNames)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if vec, ok := p.counters[cacheKey]; ok {
+		return vec
+	}
+
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name}, labelNames)
+	if err := p.registry.Register(vec); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if existing, ok := are.ExistingCollector.(*prometheus.CounterVec); ok {
+				vec = existing
+			} else {
+				logVecTypeCollision(name)
+			}
+		}
+	}
+	p.counters[cacheKey] = vec
+	return vec
+}
+
+func (p *Prometheus) histogramVec(key []string, labels []Label) *prometheus.HistogramVec {
+	name := p.name(key)
+	labelNames := p.labelNames(labels)
+	cacheKey := vecCacheKey(name, labelNames)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if vec, ok := p.histograms[cacheKey]; ok {
+		return vec
+	}
+
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name}, labelNames)
+	if err := p.registry.Register(vec); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if existing, ok := are.ExistingCollector.(*prometheus.HistogramVec); ok {
+				vec = existing
+			} else {
+				logVecTypeCollision(name)
+			}
+		}
+	}
+	p.histograms[cacheKey] = vec
+	return vec
+}
+
+func (p *Prometheus) summaryVec(key []string, labels []Label) *prometheus.SummaryVec {
+	name := p.name(key)
+	labelNames := p.labelNames(labels)
+	cacheKey := vecCacheKey(name, labelNames)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if vec, ok := p.summaries[cacheKey]; ok {
+		return vec
+	}
+
+	objectives := make(map[float64]float64, len(p.opts.Percentiles))
+	for _, pct := range p.opts.Percentiles {
+		objectives[pct] = 0.01
+	}
+
+	vec := prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       name,
+		Objectives: objectives,
+	}, labelNames)
+	if err := p.registry.Register(vec); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if existing, ok := are.ExistingCollector.(*prometheus.SummaryVec); ok {
+				vec = existing
+			} else {
+				logVecTypeCollision(name)
+			}
+		}
+	}
+	p.summaries[cacheKey] = vec
+	return vec
+}
@@ -0,0 +1,58 @@
+// +build !js
+
+package metrics
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusEmptyPrefixOmitsLeadingUnderscore(t *testing.T) {
+	p := NewPrometheus()
+	if err := p.Init(Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := p.name([]string{"my", "key"}), "my_key"; got != want {
+		t.Fatalf("name() = %q, want %q", got, want)
+	}
+}
+
+func TestPrometheusVariesLabelSetsWithoutPanicking(t *testing.T) {
+	p := NewPrometheus()
+	if err := p.Init(Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same key, no labels, then again with a label: must not panic with
+	// "inconsistent label cardinality".
+	p.SetGauge([]string{"widgets"}, 1, nil)
+	p.SetGauge([]string{"widgets"}, 2, []Label{{Name: "region", Value: "west"}})
+
+	// Same key used as both a gauge and a counter: must not panic from a
+	// duplicate registration.
+	p.IncrCounter([]string{"widgets"}, 1, nil)
+}
+
+func TestPrometheusCrossTypeCollisionIsLogged(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(log.Writer())
+
+	p := NewPrometheus()
+	if err := p.Init(Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The counter vec can't register under the name "widgets" once a
+	// gauge already owns it, so this observation is recorded locally but
+	// silently dropped from the scrape - that drop must be logged.
+	p.SetGauge([]string{"widgets"}, 1, nil)
+	p.IncrCounter([]string{"widgets"}, 1, nil)
+
+	if !strings.Contains(logs.String(), "widgets") {
+		t.Fatalf("expected a collision warning mentioning %q, got: %s", "widgets", logs.String())
+	}
+}
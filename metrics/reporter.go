@@ -0,0 +1,63 @@
+// +build !js
+
+// Package metrics defines a pluggable Reporter abstraction so that a goa
+// service can push metrics through one or more backends (Prometheus,
+// go-metrics-backed sinks, ...) without coupling call sites to a specific
+// client library.
+package metrics
+
+import (
+	"net/http"
+	"time"
+)
+
+// Label is a name/value pair attached to a metric, e.g. a Prometheus label
+// or a DogStatsD tag.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Options configures a Reporter at Init time.
+type Options struct {
+	// ServiceName identifies the reporting service, used as a default tag
+	// or namespace depending on the backend.
+	ServiceName string
+
+	// Prefix is prepended to every metric key.
+	Prefix string
+
+	// DefaultTags are attached to every metric emitted through the
+	// Reporter, in addition to any labels passed at the call site.
+	DefaultTags []Label
+
+	// Percentiles configures which percentiles a sample/timing backed
+	// summary should track, e.g. []float64{0.5, 0.9, 0.99}.
+	Percentiles []float64
+
+	// CollectTimeout bounds how long a Handler scrape is allowed to take
+	// before the backend gives up assembling the response.
+	CollectTimeout time.Duration
+}
+
+// Reporter abstracts over a metrics backend. Implementations are expected
+// to be safe for concurrent use.
+type Reporter interface {
+	// Init prepares the Reporter to receive metrics using the supplied
+	// Options. It is called once, before any other method.
+	Init(opts Options) error
+
+	SetGauge(key []string, val float32, labels []Label)
+	IncrCounter(key []string, val float32, labels []Label)
+	AddSample(key []string, val float32, labels []Label)
+	MeasureSince(key []string, start time.Time, labels []Label)
+
+	// Handler returns an http.Handler for backends that expose a scrape
+	// endpoint (e.g. Prometheus). Implementations that don't serve one
+	// return nil.
+	Handler() http.Handler
+
+	// Shutdown flushes any buffered metrics and releases resources held
+	// by the Reporter. It must be safe to call more than once.
+	Shutdown()
+}
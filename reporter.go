@@ -0,0 +1,78 @@
+// +build !js
+
+package goa
+
+import (
+	"time"
+
+	gmetrics "github.com/theshadow/goa/metrics"
+)
+
+// UseReporter initializes r with opts and routes every subsequent
+// AddSample/IncrCounter/SetGauge/MeasureSince (and their *WithLabels
+// variants) through it, the same way SetMetrics routes them through a
+// Metrics implementation. This lets goa push through the Reporter
+// subsystem - Prometheus, a fanout of several backends, ... - while the
+// existing go-metrics-backed path (NewMetrics/SetMetrics) keeps working
+// unchanged for callers who don't need it.
+func UseReporter(r gmetrics.Reporter, opts gmetrics.Options) error {
+	if err := r.Init(opts); err != nil {
+		return err
+	}
+	SetMetrics(&reporterMetrics{r: r})
+	return nil
+}
+
+// reporterMetrics adapts a gmetrics.Reporter to the Metrics interface.
+type reporterMetrics struct {
+	r gmetrics.Reporter
+}
+
+func (rm *reporterMetrics) SetGauge(key []string, val float32) {
+	rm.SetGaugeWithLabels(key, val, nil)
+}
+
+func (rm *reporterMetrics) SetGaugeWithLabels(key []string, val float32, labels []Label) {
+	rm.r.SetGauge(key, val, toReporterLabels(labels))
+}
+
+// EmitKey has no Reporter equivalent (go-metrics itself treats it as a
+// deprecated, loosely-defined "set" operation), so it's discarded rather
+// than silently mapped onto SetGauge.
+func (rm *reporterMetrics) EmitKey(key []string, val float32) {}
+
+func (rm *reporterMetrics) IncrCounter(key []string, val float32) {
+	rm.IncrCounterWithLabels(key, val, nil)
+}
+
+func (rm *reporterMetrics) IncrCounterWithLabels(key []string, val float32, labels []Label) {
+	rm.r.IncrCounter(key, val, toReporterLabels(labels))
+}
+
+func (rm *reporterMetrics) AddSample(key []string, val float32) {
+	rm.AddSampleWithLabels(key, val, nil)
+}
+
+func (rm *reporterMetrics) AddSampleWithLabels(key []string, val float32, labels []Label) {
+	rm.r.AddSample(key, val, toReporterLabels(labels))
+}
+
+func (rm *reporterMetrics) MeasureSince(key []string, start time.Time) {
+	rm.MeasureSinceWithLabels(key, start, nil)
+}
+
+func (rm *reporterMetrics) MeasureSinceWithLabels(key []string, start time.Time, labels []Label) {
+	rm.r.MeasureSince(key, start, toReporterLabels(labels))
+}
+
+func (rm *reporterMetrics) Shutdown() {
+	rm.r.Shutdown()
+}
+
+func toReporterLabels(labels []Label) []gmetrics.Label {
+	out := make([]gmetrics.Label, len(labels))
+	for i, l := range labels {
+		out[i] = gmetrics.Label{Name: l.Name, Value: l.Value}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+// +build !js
+
+package goa
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	gmetrics "github.com/theshadow/goa/metrics"
+)
+
+// recordingReporter is a gmetrics.Reporter that records what it receives,
+// used to verify UseReporter routes goa's package-level calls through it.
+type recordingReporter struct {
+	opts gmetrics.Options
+
+	gaugeKey    []string
+	gaugeVal    float32
+	gaugeLabels []gmetrics.Label
+}
+
+func (r *recordingReporter) Init(opts gmetrics.Options) error {
+	r.opts = opts
+	return nil
+}
+
+func (r *recordingReporter) SetGauge(key []string, val float32, labels []gmetrics.Label) {
+	r.gaugeKey = key
+	r.gaugeVal = val
+	r.gaugeLabels = labels
+}
+
+func (r *recordingReporter) IncrCounter(key []string, val float32, labels []gmetrics.Label)      {}
+func (r *recordingReporter) AddSample(key []string, val float32, labels []gmetrics.Label)        {}
+func (r *recordingReporter) MeasureSince(key []string, start time.Time, labels []gmetrics.Label) {}
+func (r *recordingReporter) Handler() http.Handler                                               { return nil }
+func (r *recordingReporter) Shutdown()                                                           {}
+
+func TestUseReporterRoutesThroughReporter(t *testing.T) {
+	defer SetMetrics(&metricDiscarder{})
+
+	r := &recordingReporter{}
+	if err := UseReporter(r, gmetrics.Options{ServiceName: "svc"}); err != nil {
+		t.Fatal(err)
+	}
+	if r.opts.ServiceName != "svc" {
+		t.Fatalf("expected Init to receive the supplied Options, got %+v", r.opts)
+	}
+
+	SetGaugeWithLabels([]string{"some", "key"}, 2.0, []Label{{Name: "region", Value: "west"}})
+
+	if r.gaugeVal != 2.0 {
+		t.Fatalf("expected the gauge call to reach the Reporter, got val=%v", r.gaugeVal)
+	}
+	if len(r.gaugeLabels) != 1 || r.gaugeLabels[0].Name != "region" {
+		t.Fatalf("expected labels to reach the Reporter, got %+v", r.gaugeLabels)
+	}
+}
@@ -0,0 +1,313 @@
+// +build !js
+
+package goa
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// signalDumpPercentiles are the percentiles reported for every counter,
+// sample and timing aggregate, matching go-metrics' InmemSink defaults.
+var signalDumpPercentiles = []float64{0.50, 0.90, 0.99}
+
+// EnableSignalDump installs a wrapper around the currently configured
+// Metrics implementation that tees every emitted metric into an in-memory
+// ring covering the last interval, and writes a human-readable summary of
+// that ring to w whenever sig is received. This mirrors go-metrics'
+// InmemSink/InmemSignal pattern and is useful for inspecting a running
+// service's metrics without standing up a full Prometheus stack.
+//
+// EnableSignalDump must be called after any SetMetrics/NewMetrics/
+// UseReporter call it should wrap, since it captures whatever Metrics
+// implementation is current at the time it's called.
+func EnableSignalDump(sig os.Signal, interval time.Duration, w io.Writer) {
+	dumper := &signalDumper{
+		next: current(),
+		ring: newMetricRing(interval),
+		w:    w,
+	}
+	SetMetrics(dumper)
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig)
+	go func() {
+		for range ch {
+			dumper.dump()
+		}
+	}()
+}
+
+// signalDumper delegates every call to the wrapped Metrics implementation
+// while also recording it in ring, so it can be dumped on demand.
+type signalDumper struct {
+	next Metrics
+	ring *metricRing
+	w    io.Writer
+}
+
+func (d *signalDumper) SetGauge(key []string, val float32) {
+	d.SetGaugeWithLabels(key, val, nil)
+}
+func (d *signalDumper) SetGaugeWithLabels(key []string, val float32, labels []Label) {
+	d.ring.setGauge(key, labels, val)
+	d.next.SetGaugeWithLabels(key, val, labels)
+}
+func (d *signalDumper) EmitKey(key []string, val float32) {
+	d.next.EmitKey(key, val)
+}
+func (d *signalDumper) IncrCounter(key []string, val float32) {
+	d.IncrCounterWithLabels(key, val, nil)
+}
+func (d *signalDumper) IncrCounterWithLabels(key []string, val float32, labels []Label) {
+	d.ring.incrCounter(key, labels, val)
+	d.next.IncrCounterWithLabels(key, val, labels)
+}
+func (d *signalDumper) AddSample(key []string, val float32) {
+	d.AddSampleWithLabels(key, val, nil)
+}
+func (d *signalDumper) AddSampleWithLabels(key []string, val float32, labels []Label) {
+	d.ring.addSample(key, labels, val)
+	d.next.AddSampleWithLabels(key, val, labels)
+}
+func (d *signalDumper) MeasureSince(key []string, start time.Time) {
+	d.MeasureSinceWithLabels(key, start, nil)
+}
+func (d *signalDumper) MeasureSinceWithLabels(key []string, start time.Time, labels []Label) {
+	elapsed := float32(time.Since(start)) / float32(time.Millisecond)
+	d.ring.addTiming(key, labels, elapsed)
+	d.next.MeasureSinceWithLabels(key, start, labels)
+}
+func (d *signalDumper) Shutdown() {
+	d.next.Shutdown()
+}
+
+// dump writes a human-readable summary of the ring's current contents to
+// d.w.
+func (d *signalDumper) dump() {
+	fmt.Fprintf(d.w, "[%v]\n", time.Now())
+	for name, g := range d.ring.snapshotGauges() {
+		fmt.Fprintf(d.w, "  [G] %q: %0.3f\n", name, g)
+	}
+	for name, agg := range d.ring.snapshotCounters() {
+		fmt.Fprintf(d.w, "  [C] %q: %s\n", name, agg)
+	}
+	for name, agg := range d.ring.snapshotSamples() {
+		fmt.Fprintf(d.w, "  [S] %q: %s\n", name, agg)
+	}
+	for name, agg := range d.ring.snapshotTimings() {
+		fmt.Fprintf(d.w, "  [T] %q: %s\n", name, agg)
+	}
+}
+
+// metricRing aggregates the metrics emitted over the trailing `interval` of
+// wall-clock time. It intentionally keeps a single running aggregate rather
+// than go-metrics' per-second bucket ring, since EnableSignalDump only
+// needs a coarse snapshot, not the full interval time series.
+type metricRing struct {
+	mu       sync.Mutex
+	interval time.Duration
+	resetAt  time.Time
+
+	gauges   map[string]float32
+	counters map[string]*sampleAggregate
+	samples  map[string]*sampleAggregate
+	timings  map[string]*sampleAggregate
+}
+
+func newMetricRing(interval time.Duration) *metricRing {
+	return &metricRing{
+		interval: interval,
+		resetAt:  time.Now().Add(interval),
+		gauges:   make(map[string]float32),
+		counters: make(map[string]*sampleAggregate),
+		samples:  make(map[string]*sampleAggregate),
+		timings:  make(map[string]*sampleAggregate),
+	}
+}
+
+// rotate drops all accumulated data once the interval has elapsed, so a
+// dump only ever reflects the last `interval` of activity.
+func (r *metricRing) rotate() {
+	if time.Now().Before(r.resetAt) {
+		return
+	}
+	r.resetAt = time.Now().Add(r.interval)
+	r.gauges = make(map[string]float32)
+	r.counters = make(map[string]*sampleAggregate)
+	r.samples = make(map[string]*sampleAggregate)
+	r.timings = make(map[string]*sampleAggregate)
+}
+
+func metricName(key []string, labels []Label) string {
+	name := strings.Join(key, ".")
+	if len(labels) == 0 {
+		return name
+	}
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = l.Name + "=" + l.Value
+	}
+	return name + ";" + strings.Join(parts, ",")
+}
+
+func (r *metricRing) setGauge(key []string, labels []Label, val float32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate()
+	r.gauges[metricName(key, labels)] = val
+}
+
+func (r *metricRing) incrCounter(key []string, labels []Label, val float32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate()
+	r.aggregate(r.counters, key, labels, val)
+}
+
+func (r *metricRing) addSample(key []string, labels []Label, val float32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate()
+	r.aggregate(r.samples, key, labels, val)
+}
+
+func (r *metricRing) addTiming(key []string, labels []Label, val float32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate()
+	r.aggregate(r.timings, key, labels, val)
+}
+
+func (r *metricRing) aggregate(m map[string]*sampleAggregate, key []string, labels []Label, val float32) {
+	name := metricName(key, labels)
+	agg, ok := m[name]
+	if !ok {
+		agg = &sampleAggregate{min: val, max: val}
+		m[name] = agg
+	}
+	agg.ingest(val)
+}
+
+func (r *metricRing) snapshotGauges() map[string]float32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]float32, len(r.gauges))
+	for k, v := range r.gauges {
+		out[k] = v
+	}
+	return out
+}
+
+func (r *metricRing) snapshotCounters() map[string]*sampleAggregate {
+	return snapshotAggregates(r, r.counters)
+}
+
+func (r *metricRing) snapshotSamples() map[string]*sampleAggregate {
+	return snapshotAggregates(r, r.samples)
+}
+
+func (r *metricRing) snapshotTimings() map[string]*sampleAggregate {
+	return snapshotAggregates(r, r.timings)
+}
+
+func snapshotAggregates(r *metricRing, src map[string]*sampleAggregate) map[string]*sampleAggregate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]*sampleAggregate, len(src))
+	for k, v := range src {
+		cp := *v
+		cp.values = append([]float32(nil), v.values...)
+		out[k] = &cp
+	}
+	return out
+}
+
+// sampleAggregate tracks count/min/max/mean/stddev for a stream of samples,
+// the same summary statistics go-metrics' InmemSink reports for counters,
+// samples and timings. It also keeps every raw value ingested during the
+// current interval so percentiles can be computed on demand; like the rest
+// of the aggregate, this is bounded by rotate() resetting it each interval
+// rather than by any cap of its own, so it always covers the same window as
+// mean/stddev/min/max.
+type sampleAggregate struct {
+	count  int
+	sum    float64
+	sumSq  float64
+	min    float32
+	max    float32
+	values []float32
+}
+
+func (a *sampleAggregate) ingest(val float32) {
+	a.count++
+	a.sum += float64(val)
+	a.sumSq += float64(val) * float64(val)
+	if val < a.min {
+		a.min = val
+	}
+	if val > a.max {
+		a.max = val
+	}
+	a.values = append(a.values, val)
+}
+
+// percentiles returns the value at each of signalDumpPercentiles, in order,
+// computed by sorting the current reservoir. It returns nil if no samples
+// have been ingested.
+func (a *sampleAggregate) percentiles() []float64 {
+	if len(a.values) == 0 {
+		return nil
+	}
+
+	sorted := append([]float32(nil), a.values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	out := make([]float64, len(signalDumpPercentiles))
+	for i, p := range signalDumpPercentiles {
+		idx := int(p * float64(len(sorted)-1))
+		out[i] = float64(sorted[idx])
+	}
+	return out
+}
+
+func (a *sampleAggregate) mean() float64 {
+	if a.count == 0 {
+		return 0
+	}
+	return a.sum / float64(a.count)
+}
+
+func (a *sampleAggregate) stddev() float64 {
+	if a.count < 2 {
+		return 0
+	}
+	variance := (a.sumSq / float64(a.count)) - (a.mean() * a.mean())
+	if variance < 0 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
+
+func (a *sampleAggregate) String() string {
+	base := fmt.Sprintf("count=%d sum=%0.3f mean=%0.3f stddev=%0.3f min=%0.3f max=%0.3f",
+		a.count, a.sum, a.mean(), a.stddev(), a.min, a.max)
+
+	pcts := a.percentiles()
+	if pcts == nil {
+		return base
+	}
+
+	parts := make([]string, len(pcts))
+	for i, p := range signalDumpPercentiles {
+		parts[i] = fmt.Sprintf("p%g=%0.3f", p*100, pcts[i])
+	}
+	return base + " " + strings.Join(parts, " ")
+}
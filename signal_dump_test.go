@@ -0,0 +1,38 @@
+// +build !js
+
+package goa
+
+import "testing"
+
+func TestSampleAggregatePercentiles(t *testing.T) {
+	agg := &sampleAggregate{min: 1, max: 1}
+	for i := 1; i <= 100; i++ {
+		agg.ingest(float32(i))
+	}
+
+	pcts := agg.percentiles()
+	if len(pcts) != len(signalDumpPercentiles) {
+		t.Fatalf("expected %d percentiles, got %d", len(signalDumpPercentiles), len(pcts))
+	}
+	if got, want := pcts[0], 50.0; got != want {
+		t.Fatalf("p50 = %v, want %v", got, want)
+	}
+	if got, want := pcts[2], 99.0; got != want {
+		t.Fatalf("p99 = %v, want %v", got, want)
+	}
+}
+
+func TestSampleAggregatePercentilesCoverSameWindowAsOtherStats(t *testing.T) {
+	agg := &sampleAggregate{min: 0, max: 0}
+	const n = 1000
+	for i := 0; i < n; i++ {
+		agg.ingest(float32(i))
+	}
+
+	if agg.count != n {
+		t.Fatalf("count = %d, want %d", agg.count, n)
+	}
+	if len(agg.values) != n {
+		t.Fatalf("expected percentiles to cover every ingested value like count/mean/stddev do, got %d of %d", len(agg.values), n)
+	}
+}